@@ -0,0 +1,29 @@
+package git
+
+import "context"
+
+// Backend abstracts how Service talks to the repository. ExecBackend (the
+// default) shells out to the git binary for every call; GoGitBackend reads
+// the repository directly from disk via go-git, trading some completeness
+// for avoiding a fork+exec per call. Service methods that need git-CLI-only
+// features (streaming, format-patch, commit ranges, ...) require an
+// ExecBackend specifically and say so in their doc comments. GetDiff works
+// with any Backend, but GoGitBackend ignores DiffOptions.DetectRenames and
+// DiffOptions.IncludeBinary.
+type Backend interface {
+	// RunGitCommand runs an arbitrary git subcommand and returns its
+	// stdout as-is (not trimmed). Backends that don't wrap the git binary may not be
+	// able to honor every subcommand a caller passes.
+	RunGitCommand(args ...string) (string, error)
+
+	// GetDiff computes the working-tree diff of the given type. ctx may be
+	// used to cancel a long-running computation.
+	GetDiff(ctx context.Context, diffType DiffType, opts DiffOptions) (*DiffResult, error)
+
+	// GetFileContent returns a file's content at HEAD, falling back to its
+	// working tree copy if it isn't tracked yet.
+	GetFileContent(filePath string) (string, error)
+
+	// GetUntrackedFiles lists paths git doesn't yet track.
+	GetUntrackedFiles() ([]string, error)
+}
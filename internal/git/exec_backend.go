@@ -0,0 +1,310 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ExecBackend implements Backend by shelling out to the git binary.
+type ExecBackend struct{}
+
+// NewExecBackend constructs an ExecBackend.
+func NewExecBackend() *ExecBackend {
+	return &ExecBackend{}
+}
+
+func (b *ExecBackend) RunGitCommand(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %s", stderr.String())
+	}
+
+	return out.String(), nil
+}
+
+// runGitCommandStream starts a git command and returns its stdout as a
+// pipe for incremental reading, along with a wait function that must be
+// called after the caller is done reading the pipe. wait blocks until the
+// process exits and surfaces any failure (including stderr output).
+func (b *ExecBackend) runGitCommandStream(ctx context.Context, args ...string) (io.Reader, func() error, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open git stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start git command: %w", err)
+	}
+
+	wait := func() error {
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("git command failed: %s", stderr.String())
+		}
+		return nil
+	}
+
+	return stdout, wait, nil
+}
+
+func (b *ExecBackend) GetFileContent(filePath string) (string, error) {
+	// First try the file's content at HEAD
+	content, err := b.RunGitCommand("show", fmt.Sprintf("HEAD:%s", filePath))
+	if err != nil {
+		// If not in HEAD, try to read from filesystem
+		output, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return string(output), nil
+	}
+	return content, nil
+}
+
+// GetUntrackedFiles returns the list of untracked files from git status.
+func (b *ExecBackend) GetUntrackedFiles() ([]string, error) {
+	output, err := b.RunGitCommand("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	if output == "" {
+		return []string{}, nil
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	var files []string
+	for _, line := range lines {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
+// GetDiff computes the working-tree diff of the given type, buffering
+// every FileDiff from StreamDiff into a slice.
+func (b *ExecBackend) GetDiff(ctx context.Context, diffType DiffType, opts DiffOptions) (*DiffResult, error) {
+	var files []FileDiff
+	err := b.StreamDiff(ctx, diffType, func(fd FileDiff) error {
+		files = append(files, fd)
+		return nil
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiffResult{
+		Files: files,
+		Type:  diffType,
+	}, nil
+}
+
+// StreamDiff runs git diff for diffType and invokes fn once per completed
+// FileDiff as soon as it is parsed, so callers never need to hold the whole
+// diff output (or the full file list) in memory at once. ctx can be used to
+// cancel a long-running git invocation, e.g. on an enormous repository.
+func (b *ExecBackend) StreamDiff(ctx context.Context, diffType DiffType, fn func(FileDiff) error, opts DiffOptions) error {
+	var args []string
+	switch diffType {
+	case DiffTypeStaged:
+		args = []string{"diff", "--cached", "--no-color", "--no-ext-diff"}
+	case DiffTypeUnstaged:
+		args = []string{"diff", "--no-color", "--no-ext-diff"}
+	default:
+		args = []string{"diff", "HEAD", "--no-color", "--no-ext-diff"}
+	}
+	args = applyDiffFlags(args, opts)
+
+	fileCount := 0
+	countingFn := func(fd FileDiff) error {
+		fileCount++
+		return fn(fd)
+	}
+
+	if err := b.runDiffParsed(ctx, args, opts, countingFn); err != nil {
+		return err
+	}
+
+	// Get untracked files and add them to the diff, subject to the same
+	// MaxFiles cap diffParser applies to the tracked files above.
+	if diffType == DiffTypeUnstaged || diffType == DiffTypeAll {
+		contextLines := 3
+		if opts.ContextLines != nil {
+			contextLines = *opts.ContextLines
+		}
+
+		untrackedFiles, err := b.GetUntrackedFiles()
+		if err == nil && len(untrackedFiles) > 0 {
+			for _, filepath := range untrackedFiles {
+				if opts.MaxFiles > 0 && fileCount >= opts.MaxFiles {
+					break
+				}
+				fileDiff, err := getUntrackedFileDiff(filepath, contextLines)
+				if err == nil && fileDiff != nil {
+					fileCount++
+					if err := fn(*fileDiff); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetCommitDiff returns the diff introduced by a single commit, equivalent
+// to `git show <ref>`.
+func (b *ExecBackend) GetCommitDiff(ref string, opts DiffOptions) (*DiffResult, error) {
+	args := applyDiffFlags([]string{"show", ref, "--no-color", "--no-ext-diff"}, opts)
+
+	var files []FileDiff
+	err := b.runDiffParsed(context.Background(), args, opts, func(fd FileDiff) error {
+		files = append(files, fd)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiffResult{Files: files}, nil
+}
+
+// GetRangeDiff returns the diff between two commits, equivalent to
+// `git diff <from>..<to>`.
+func (b *ExecBackend) GetRangeDiff(from, to string, opts DiffOptions) (*DiffResult, error) {
+	args := applyDiffFlags([]string{"diff", "--no-color", "--no-ext-diff", fmt.Sprintf("%s..%s", from, to)}, opts)
+
+	var files []FileDiff
+	err := b.runDiffParsed(context.Background(), args, opts, func(fd FileDiff) error {
+		files = append(files, fd)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiffResult{Files: files}, nil
+}
+
+// GetBlobDiff diffs two blob objects directly by SHA, independent of any
+// commit, branch, or path, equivalent to `git diff <oldSha> <newSha>`.
+func (b *ExecBackend) GetBlobDiff(oldSha, newSha string) (*FileDiff, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", oldSha, newSha}
+
+	var file *FileDiff
+	err := b.runDiffParsed(context.Background(), args, DiffOptions{}, func(fd FileDiff) error {
+		if file == nil {
+			file = &fd
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("no diff produced for blobs %s..%s", oldSha, newSha)
+	}
+
+	return file, nil
+}
+
+// GetRawDiff writes the raw textual diff for spec to w without going through
+// FileDiff at all, so callers (e.g. an HTTP handler serving a patch
+// download) can stream git's own output straight through.
+func (b *ExecBackend) GetRawDiff(spec DiffSpec, format RawDiffFormat, w io.Writer) error {
+	to := spec.To
+	if to == "" {
+		to = "HEAD"
+	}
+
+	var args []string
+	switch format {
+	case RawDiffPatch:
+		args = []string{"format-patch", "--stdout"}
+		if spec.From != "" {
+			args = append(args, fmt.Sprintf("%s..%s", spec.From, to))
+		} else {
+			args = append(args, "-1", spec.Ref)
+		}
+	default:
+		if spec.From != "" {
+			args = []string{"diff", "--no-color", "--no-ext-diff", fmt.Sprintf("%s..%s", spec.From, to)}
+		} else {
+			args = []string{"show", spec.Ref, "--no-color", "--no-ext-diff"}
+		}
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git command failed: %s", stderr.String())
+	}
+	return nil
+}
+
+// applyDiffFlags appends the flags common to every diff invocation
+// (context lines, rename/copy detection, binary patches) driven by opt to
+// the given base git diff arguments.
+func applyDiffFlags(args []string, opt DiffOptions) []string {
+	contextLines := 3
+	if opt.ContextLines != nil {
+		contextLines = *opt.ContextLines
+	}
+	if contextLines >= 0 {
+		args = append(args, fmt.Sprintf("-U%d", contextLines))
+	}
+
+	if opt.DetectRenames {
+		similarity := opt.RenameSimilarity
+		if similarity <= 0 {
+			similarity = 50
+		}
+		args = append(args, fmt.Sprintf("-M%d%%", similarity), fmt.Sprintf("-C%d%%", similarity))
+	}
+
+	if opt.IncludeBinary {
+		args = append(args, "--binary")
+	}
+
+	return args
+}
+
+// runDiffParsed runs a git diff-family command and feeds its output through
+// diffParser, invoking fn once per parsed FileDiff.
+func (b *ExecBackend) runDiffParsed(ctx context.Context, args []string, opt DiffOptions, fn func(FileDiff) error) error {
+	stdout, wait, err := b.runGitCommandStream(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	parseErr := newDiffParser(stdout, opt).parse(fn)
+	waitErr := wait()
+
+	if parseErr != nil {
+		return fmt.Errorf("failed to parse diff: %w", parseErr)
+	}
+	if waitErr != nil {
+		return fmt.Errorf("failed to get diff: %w", waitErr)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+// GoGitBackend implements Backend by reading the repository directly from
+// disk via go-git. RunGitCommand is unsupported, since it never shells out
+// to git, and GetDiff computes hunks itself (via a line-level LCS, see
+// linediff.go) rather than reusing the "diff --git" text format, so it
+// doesn't support rename/copy detection or binary patches the way
+// ExecBackend's DiffOptions do.
+type GoGitBackend struct {
+	repo *gogit.Repository
+}
+
+// NewGoGitBackend opens the git repository at path (or a parent directory
+// of it, since DetectDotGit walks upward looking for .git).
+func NewGoGitBackend(path string) (*GoGitBackend, error) {
+	repo, err := gogit.PlainOpenWithOptions(path, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	return &GoGitBackend{repo: repo}, nil
+}
+
+// RunGitCommand is not supported: GoGitBackend never shells out to git.
+func (b *GoGitBackend) RunGitCommand(args ...string) (string, error) {
+	return "", fmt.Errorf("RunGitCommand is not supported by GoGitBackend (args: %v)", args)
+}
+
+func (b *GoGitBackend) GetFileContent(filePath string) (string, error) {
+	if content, ok, err := b.headFileContent(filePath); err == nil && ok {
+		return content, nil
+	}
+
+	content, ok, err := b.worktreeFileContent(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if !ok {
+		return "", fmt.Errorf("failed to read file: %s not found", filePath)
+	}
+	return content, nil
+}
+
+// GetUntrackedFiles lists paths git doesn't yet track.
+func (b *GoGitBackend) GetUntrackedFiles() ([]string, error) {
+	wt, err := b.repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	var files []string
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == gogit.Untracked {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// GetDiff computes the working-tree diff of the given type directly from
+// the object database and index, without forking a git process. ctx is
+// accepted to satisfy Backend but isn't honored; each call reads the
+// repository it already has open. WordDiff is supported; DetectRenames and
+// IncludeBinary are not (see GoGitBackend's doc comment).
+func (b *GoGitBackend) GetDiff(ctx context.Context, diffType DiffType, opts DiffOptions) (*DiffResult, error) {
+	contextLines := 3
+	if opts.ContextLines != nil {
+		contextLines = *opts.ContextLines
+	}
+
+	wt, err := b.repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	var files []FileDiff
+	for path, fileStatus := range status {
+		if !diffTypeMatchesStatus(diffType, fileStatus) {
+			continue
+		}
+
+		fd, err := b.diffPath(path, diffType, contextLines, opts.WordDiff)
+		if err != nil || fd == nil {
+			continue
+		}
+		files = append(files, *fd)
+	}
+
+	return &DiffResult{Files: files, Type: diffType}, nil
+}
+
+func diffTypeMatchesStatus(diffType DiffType, fileStatus *gogit.FileStatus) bool {
+	switch diffType {
+	case DiffTypeStaged:
+		return fileStatus.Staging != gogit.Unmodified
+	case DiffTypeUnstaged:
+		return fileStatus.Worktree != gogit.Unmodified
+	default:
+		return fileStatus.Staging != gogit.Unmodified || fileStatus.Worktree != gogit.Unmodified
+	}
+}
+
+// diffPath builds a FileDiff for path by reading the two content versions
+// diffType compares and aligning them line-by-line.
+func (b *GoGitBackend) diffPath(path string, diffType DiffType, contextLines int, wordDiff bool) (*FileDiff, error) {
+	var oldContent, newContent string
+	var oldOK, newOK bool
+	var err error
+
+	switch diffType {
+	case DiffTypeStaged:
+		if oldContent, oldOK, err = b.headFileContent(path); err != nil {
+			return nil, err
+		}
+		if newContent, newOK, err = b.indexFileContent(path); err != nil {
+			return nil, err
+		}
+	case DiffTypeUnstaged:
+		if oldContent, oldOK, err = b.indexFileContent(path); err != nil {
+			return nil, err
+		}
+		if !oldOK {
+			if oldContent, oldOK, err = b.headFileContent(path); err != nil {
+				return nil, err
+			}
+		}
+		if newContent, newOK, err = b.worktreeFileContent(path); err != nil {
+			return nil, err
+		}
+	default: // DiffTypeAll
+		if oldContent, oldOK, err = b.headFileContent(path); err != nil {
+			return nil, err
+		}
+		if newContent, newOK, err = b.worktreeFileContent(path); err != nil {
+			return nil, err
+		}
+	}
+
+	status := FileStatusModified
+	switch {
+	case !oldOK && newOK:
+		status = FileStatusAdded
+	case oldOK && !newOK:
+		status = FileStatusDeleted
+	}
+
+	hunks := buildHunks(diffLineOps(splitFileLines(oldContent, oldOK), splitFileLines(newContent, newOK)), contextLines)
+	if len(hunks) == 0 {
+		return nil, nil
+	}
+
+	fd := &FileDiff{
+		Path:    path,
+		OldPath: path,
+		Status:  status,
+		Hunks:   hunks,
+	}
+	for i := range fd.Hunks {
+		if wordDiff {
+			assignWordDiffSegments(fd.Hunks[i].Lines)
+		}
+		for _, line := range fd.Hunks[i].Lines {
+			switch line.Type {
+			case LineTypeAdded:
+				fd.Additions++
+			case LineTypeDeleted:
+				fd.Deletions++
+			}
+		}
+	}
+	return fd, nil
+}
+
+func splitFileLines(content string, ok bool) []string {
+	if !ok || content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// headFileContent reads path's content from the HEAD commit's tree. ok is
+// false if there is no HEAD yet or the file isn't present in it.
+func (b *GoGitBackend) headFileContent(path string) (content string, ok bool, err error) {
+	head, err := b.repo.Head()
+	if err != nil {
+		return "", false, nil
+	}
+	commit, err := b.repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", false, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return "", false, nil
+	}
+	content, err = file.Contents()
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// indexFileContent reads path's staged content from the git index. ok is
+// false if the file isn't staged.
+func (b *GoGitBackend) indexFileContent(path string) (content string, ok bool, err error) {
+	idx, err := b.repo.Storer.Index()
+	if err != nil {
+		return "", false, err
+	}
+	entry, err := idx.Entry(path)
+	if err != nil {
+		return "", false, nil
+	}
+	blob, err := b.repo.BlobObject(entry.Hash)
+	if err != nil {
+		return "", false, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return "", false, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// worktreeFileContent reads path's content from the working tree. ok is
+// false if the file doesn't exist on disk.
+func (b *GoGitBackend) worktreeFileContent(path string) (content string, ok bool, err error) {
+	wt, err := b.repo.Worktree()
+	if err != nil {
+		return "", false, err
+	}
+	f, err := wt.Filesystem.Open(path)
+	if err != nil {
+		return "", false, nil
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
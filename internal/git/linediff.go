@@ -0,0 +1,216 @@
+package git
+
+import "fmt"
+
+// lineOp is one step of an alignment between an old and a new version of a
+// file, produced by diffLineOps. Exactly one of oldLine/newLine is zero for
+// a deletion/addition; both are set (and equal in content) for a line that
+// is unchanged between the two versions.
+type lineOp struct {
+	equal   bool
+	oldLine int // 1-based; 0 if this op has no old-side line
+	newLine int // 1-based; 0 if this op has no new-side line
+	content string
+}
+
+// maxLineDiffLCSCells caps the size (oldLines x newLines) of the LCS table
+// diffLineOpsMiddle is willing to allocate. Above this, it falls back to
+// diffLineOpsCoarse instead of the O(n*m) table, so a large file with a
+// large change doesn't exhaust memory computing a minimal diff.
+const maxLineDiffLCSCells = 4_000_000
+
+// diffLineOps aligns oldLines and newLines via a longest-common-subsequence
+// table, the same approach wordDiff uses at the token level, just applied
+// to whole lines instead. It first trims the common prefix and suffix, so
+// the expensive part of the algorithm only runs over the lines that
+// actually differ rather than the whole file — the common case of a small
+// edit in a large file costs O(n+m), not O(n*m).
+func diffLineOps(oldLines, newLines []string) []lineOp {
+	start := 0
+	for start < len(oldLines) && start < len(newLines) && oldLines[start] == newLines[start] {
+		start++
+	}
+
+	oldEnd, newEnd := len(oldLines), len(newLines)
+	for oldEnd > start && newEnd > start && oldLines[oldEnd-1] == newLines[newEnd-1] {
+		oldEnd--
+		newEnd--
+	}
+
+	var ops []lineOp
+	for i := 0; i < start; i++ {
+		ops = append(ops, lineOp{equal: true, oldLine: i + 1, newLine: i + 1, content: oldLines[i]})
+	}
+
+	ops = append(ops, diffLineOpsMiddle(oldLines[start:oldEnd], newLines[start:newEnd], start)...)
+
+	for i := oldEnd; i < len(oldLines); i++ {
+		ops = append(ops, lineOp{equal: true, oldLine: i + 1, newLine: newEnd + (i - oldEnd) + 1, content: oldLines[i]})
+	}
+
+	return ops
+}
+
+// diffLineOpsMiddle runs the LCS alignment over the (already prefix/suffix
+// trimmed) middle section of two files. lineOffset is how many lines were
+// trimmed off the front, added back to make 1-based line numbers line up
+// with the original files.
+func diffLineOpsMiddle(oldMid, newMid []string, lineOffset int) []lineOp {
+	n, m := len(oldMid), len(newMid)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	if n*m > maxLineDiffLCSCells {
+		return diffLineOpsCoarse(oldMid, newMid, lineOffset)
+	}
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case oldMid[i] == newMid[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []lineOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldMid[i] == newMid[j]:
+			ops = append(ops, lineOp{equal: true, oldLine: lineOffset + i + 1, newLine: lineOffset + j + 1, content: oldMid[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, lineOp{oldLine: lineOffset + i + 1, content: oldMid[i]})
+			i++
+		default:
+			ops = append(ops, lineOp{newLine: lineOffset + j + 1, content: newMid[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, lineOp{oldLine: lineOffset + i + 1, content: oldMid[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, lineOp{newLine: lineOffset + j + 1, content: newMid[j]})
+	}
+
+	return ops
+}
+
+// diffLineOpsCoarse is the O(n+m) fallback for a middle section too large
+// for the LCS table: every old line is a deletion followed by every new
+// line as an addition, with no attempt to match up unchanged lines within
+// the section. It trades a minimal diff for bounded memory and time.
+func diffLineOpsCoarse(oldMid, newMid []string, lineOffset int) []lineOp {
+	ops := make([]lineOp, 0, len(oldMid)+len(newMid))
+	for i, line := range oldMid {
+		ops = append(ops, lineOp{oldLine: lineOffset + i + 1, content: line})
+	}
+	for j, line := range newMid {
+		ops = append(ops, lineOp{newLine: lineOffset + j + 1, content: line})
+	}
+	return ops
+}
+
+// buildHunks groups an aligned op sequence into windowed Hunks, mirroring
+// what `git diff -U<contextLines>` does: each changed region keeps up to
+// contextLines of surrounding unchanged lines, and windows that overlap as
+// a result are merged into a single hunk.
+func buildHunks(ops []lineOp, contextLines int) []Hunk {
+	var changedIdx []int
+	for idx, op := range ops {
+		if !op.equal {
+			changedIdx = append(changedIdx, idx)
+		}
+	}
+	if len(changedIdx) == 0 {
+		return nil
+	}
+
+	type window struct{ start, end int } // [start, end) indices into ops
+	var windows []window
+
+	start := clampInt(changedIdx[0]-contextLines, 0, len(ops))
+	end := clampInt(changedIdx[0]+1+contextLines, 0, len(ops))
+	for _, idx := range changedIdx[1:] {
+		winStart := clampInt(idx-contextLines, 0, len(ops))
+		winEnd := clampInt(idx+1+contextLines, 0, len(ops))
+		if winStart <= end {
+			end = winEnd
+			continue
+		}
+		windows = append(windows, window{start, end})
+		start, end = winStart, winEnd
+	}
+	windows = append(windows, window{start, end})
+
+	hunks := make([]Hunk, 0, len(windows))
+	for _, win := range windows {
+		hunks = append(hunks, hunkFromOps(ops[win.start:win.end]))
+	}
+	return hunks
+}
+
+func hunkFromOps(ops []lineOp) Hunk {
+	var lines []Line
+	var oldStart, newStart, oldCount, newCount int
+
+	for _, op := range ops {
+		switch {
+		case op.equal:
+			if oldStart == 0 {
+				oldStart = op.oldLine
+			}
+			if newStart == 0 {
+				newStart = op.newLine
+			}
+			oldNum, newNum := op.oldLine, op.newLine
+			lines = append(lines, Line{Type: LineTypeContext, OldNumber: &oldNum, NewNumber: &newNum, Content: op.content})
+			oldCount++
+			newCount++
+		case op.oldLine != 0:
+			if oldStart == 0 {
+				oldStart = op.oldLine
+			}
+			oldNum := op.oldLine
+			lines = append(lines, Line{Type: LineTypeDeleted, OldNumber: &oldNum, Content: op.content})
+			oldCount++
+		default:
+			if newStart == 0 {
+				newStart = op.newLine
+			}
+			newNum := op.newLine
+			lines = append(lines, Line{Type: LineTypeAdded, NewNumber: &newNum, Content: op.content})
+			newCount++
+		}
+	}
+
+	return Hunk{
+		OldStart: oldStart,
+		OldLines: oldCount,
+		NewStart: newStart,
+		NewLines: newCount,
+		Header:   fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount),
+		Lines:    lines,
+	}
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffLineOpsTrimsCommonPrefixAndSuffix(t *testing.T) {
+	const size = 20000
+	oldLines := make([]string, size)
+	newLines := make([]string, size)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("line%d", i)
+		newLines[i] = oldLines[i]
+	}
+	newLines[size/2] = "changed"
+
+	ops := diffLineOps(oldLines, newLines)
+	hunks := buildHunks(ops, 3)
+	if len(hunks) != 1 {
+		t.Fatalf("got %d hunks, want 1 for a single changed line", len(hunks))
+	}
+
+	var changed int
+	for _, line := range hunks[0].Lines {
+		if line.Type != LineTypeContext {
+			changed++
+		}
+	}
+	if changed != 2 {
+		t.Errorf("hunk has %d non-context lines, want 2 (one deletion, one addition)", changed)
+	}
+}
+
+func TestDiffLineOpsCoarseFallback(t *testing.T) {
+	// Large enough that n*m exceeds maxLineDiffLCSCells, forcing the coarse
+	// fallback even though the prefix/suffix trim in diffLineOps leaves
+	// nothing in common to trim away.
+	const n, m = 2500, 2500
+	oldLines := make([]string, n)
+	newLines := make([]string, m)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("old%d", i)
+	}
+	for j := range newLines {
+		newLines[j] = fmt.Sprintf("new%d", j)
+	}
+
+	ops := diffLineOps(oldLines, newLines)
+	if len(ops) != n+m {
+		t.Fatalf("got %d ops, want %d (coarse fallback: every line as a delete or add)", len(ops), n+m)
+	}
+	for i, op := range ops {
+		if i < n {
+			if op.equal || op.oldLine != i+1 {
+				t.Fatalf("op[%d] = %+v, want a deletion of old line %d", i, op, i+1)
+			}
+		} else {
+			j := i - n
+			if op.equal || op.newLine != j+1 {
+				t.Fatalf("op[%d] = %+v, want an addition of new line %d", i, op, j+1)
+			}
+		}
+	}
+}
@@ -1,39 +1,179 @@
 package git
 
 import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+const (
+	// initialScanBufSize is the starting size of the bufio.Scanner buffer.
+	initialScanBufSize = 64 * 1024
+	// defaultMaxScanBufSize is the largest single line the scanner will
+	// grow its buffer to accommodate, absent a smaller MaxLineLength.
+	defaultMaxScanBufSize = 16 * 1024 * 1024
+)
+
+var (
+	// diffGitLineRegex matches the common unquoted form of a "diff --git"
+	// line. Paths containing spaces or non-ASCII bytes are instead quoted
+	// by git and handled separately in parseDiffGitPaths.
+	diffGitLineRegex = regexp.MustCompile(`diff --git [a-z]/(.+) [a-z]/(.+)`)
+	hunkHeaderRegex  = regexp.MustCompile(`@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)`)
+)
+
+// parseDiffGitPaths extracts the old and new paths from a "diff --git" line.
+// It handles both the common unquoted "a/path b/path" form and the quoted
+// "a/path with spaces" "b/..." form git emits (with core.quotePath escapes)
+// when either path contains whitespace or non-ASCII bytes.
+func parseDiffGitPaths(line string) (oldPath, newPath string, ok bool) {
+	const prefix = "diff --git "
+	rest, found := strings.CutPrefix(line, prefix)
+	if !found {
+		return "", "", false
+	}
+
+	if strings.HasPrefix(rest, `"`) {
+		oldQuoted, remainder, ok := cutQuotedToken(rest)
+		if !ok {
+			return "", "", false
+		}
+		newQuoted, _, ok := cutQuotedToken(strings.TrimPrefix(remainder, " "))
+		if !ok {
+			return "", "", false
+		}
+		oldPath = strings.TrimPrefix(unquoteHeaderPath(oldQuoted), "a/")
+		newPath = strings.TrimPrefix(unquoteHeaderPath(newQuoted), "b/")
+		return oldPath, newPath, true
+	}
+
+	matches := diffGitLineRegex.FindStringSubmatch(line)
+	if len(matches) < 3 {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+// cutQuotedToken splits a double-quoted token (including its quotes) from
+// the front of s, honoring backslash escapes, and returns the remainder.
+func cutQuotedToken(s string) (quoted, remainder string, ok bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", s, false
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], s[i+1:], true
+		}
+	}
+	return "", s, false
+}
+
+// unquoteHeaderPath removes the C-style quoting git applies to diff header
+// paths that contain whitespace or non-ASCII bytes. Paths that are not
+// quoted are returned unchanged.
+func unquoteHeaderPath(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+// diffParser consumes unified diff text line-by-line from an io.Reader, so
+// memory use stays bounded by a single line rather than the whole diff.
 type diffParser struct {
-	lines   []string
-	current int
+	scanner   *bufio.Scanner
+	opts      DiffOptions
+	peeked    *string
+	scanErr   error
+	fileCount int
 }
 
-func newDiffParser(diff string) *diffParser {
+func newDiffParser(r io.Reader, opts DiffOptions) *diffParser {
+	scanner := bufio.NewScanner(r)
+
+	maxBuf := defaultMaxScanBufSize
+	if opts.MaxLineLength > 0 && opts.MaxLineLength+1024 < maxBuf {
+		maxBuf = opts.MaxLineLength + 1024
+	}
+	scanner.Buffer(make([]byte, 0, initialScanBufSize), maxBuf)
+
 	return &diffParser{
-		lines: strings.Split(diff, "\n"),
+		scanner: scanner,
+		opts:    opts,
 	}
 }
 
-func (p *diffParser) parse() ([]FileDiff, error) {
-	var files []FileDiff
+// peek returns the next unconsumed line without advancing past it.
+func (p *diffParser) peek() (string, bool) {
+	if p.peeked != nil {
+		return *p.peeked, true
+	}
+	if p.scanner.Scan() {
+		line := p.scanner.Text()
+		if p.opts.MaxLineLength > 0 && len(line) > p.opts.MaxLineLength {
+			line = line[:p.opts.MaxLineLength]
+		}
+		p.peeked = &line
+		return line, true
+	}
+	p.scanErr = p.scanner.Err()
+	return "", false
+}
 
-	for p.current < len(p.lines) {
-		line := p.lines[p.current]
+// next returns the next unconsumed line and advances past it.
+func (p *diffParser) next() (string, bool) {
+	line, ok := p.peek()
+	if ok {
+		p.peeked = nil
+	}
+	return line, ok
+}
+
+// parse streams the diff, invoking fn once per completed FileDiff. It stops
+// early once opts.MaxFiles have been emitted, if set.
+func (p *diffParser) parse(fn func(FileDiff) error) error {
+	for {
+		line, ok := p.peek()
+		if !ok {
+			break
+		}
+
+		if !strings.HasPrefix(line, "diff --git") {
+			p.next()
+			continue
+		}
 
-		if strings.HasPrefix(line, "diff --git") {
-			file := p.parseFile()
-			if file != nil {
-				files = append(files, *file)
+		if p.opts.MaxFiles > 0 && p.fileCount >= p.opts.MaxFiles {
+			break
+		}
+
+		file := p.parseFile()
+		if file == nil {
+			continue
+		}
+		p.fileCount++
+
+		if fn != nil {
+			if err := fn(*file); err != nil {
+				return err
 			}
-		} else {
-			p.current++
 		}
 	}
 
-	return files, nil
+	if p.scanErr != nil {
+		return fmt.Errorf("error reading diff: %w", p.scanErr)
+	}
+	return nil
 }
 
 func (p *diffParser) parseFile() *FileDiff {
@@ -41,34 +181,63 @@ func (p *diffParser) parseFile() *FileDiff {
 		Hunks: []Hunk{},
 	}
 
-	diffLine := p.lines[p.current]
-	paths := regexp.MustCompile(`diff --git [a-z]/(.+) [a-z]/(.+)`).FindStringSubmatch(diffLine)
-	if len(paths) >= 3 {
-		file.OldPath = paths[1]
-		file.Path = paths[2]
+	diffLine, ok := p.next()
+	if !ok {
+		return nil
+	}
+	if oldPath, newPath, ok := parseDiffGitPaths(diffLine); ok {
+		file.OldPath = oldPath
+		file.Path = newPath
 	}
-	p.current++
 
-	for p.current < len(p.lines) && !strings.HasPrefix(p.lines[p.current], "diff --git") {
-		line := p.lines[p.current]
+	for {
+		line, ok := p.peek()
+		if !ok || strings.HasPrefix(line, "diff --git") {
+			break
+		}
 
 		switch {
 		case strings.HasPrefix(line, "new file"):
+			p.next()
 			file.Status = FileStatusAdded
 		case strings.HasPrefix(line, "deleted file"):
+			p.next()
 			file.Status = FileStatusDeleted
 		case strings.HasPrefix(line, "rename from"):
+			p.next()
 			file.Status = FileStatusRenamed
-			file.OldPath = strings.TrimPrefix(line, "rename from ")
+			file.OldPath = unquoteHeaderPath(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to"):
+			p.next()
+			file.Path = unquoteHeaderPath(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy from"):
+			p.next()
+			file.Status = FileStatusCopied
+			file.OldPath = unquoteHeaderPath(strings.TrimPrefix(line, "copy from "))
+		case strings.HasPrefix(line, "copy to"):
+			p.next()
+			file.Path = unquoteHeaderPath(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "similarity index"):
+			p.next()
+			pct := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, "similarity index")), "%")
+			if v, err := strconv.Atoi(pct); err == nil {
+				file.Similarity = v
+			}
 		case strings.HasPrefix(line, "Binary files"):
+			p.next()
+			file.IsBinary = true
+		case strings.HasPrefix(line, "GIT binary patch"):
+			p.next()
 			file.IsBinary = true
+			file.BinaryPatch, file.BinaryPatchIsDelta = p.parseBinaryPatch()
 		case strings.HasPrefix(line, "@@"):
-			if hunk := p.parseHunk(); hunk != nil {
+			p.next()
+			if hunk := p.parseHunk(line); hunk != nil {
 				file.Hunks = append(file.Hunks, *hunk)
-				continue
 			}
+		default:
+			p.next()
 		}
-		p.current++
 	}
 
 	if file.Status == "" {
@@ -86,12 +255,132 @@ func (p *diffParser) parseFile() *FileDiff {
 		}
 	}
 
+	if !file.IsBinary && (file.Status == FileStatusAdded || file.Status == FileStatusModified) {
+		file.LFS = detectLFSPointer(file.Hunks)
+	}
+
 	return file
 }
 
-func (p *diffParser) parseHunk() *Hunk {
-	header := p.lines[p.current]
-	matches := regexp.MustCompile(`@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)`).FindStringSubmatch(header)
+// parseBinaryPatch consumes the base85-encoded block(s) following a "GIT
+// binary patch" header and decodes the first block (the forward patch,
+// describing the new content) into raw bytes: base85-decoded, then
+// zlib-inflated, the reverse of the order git encodes them in. Git emits a
+// second, reverse block after a blank line; it is left for the outer
+// parseFile loop to skip since FileDiff only needs the forward direction.
+//
+// isDelta reports whether the block was a "delta N" block, meaning the
+// returned bytes are a delta against the pre-image blob rather than
+// literal content; this parser only sees the diff text stream, so it has
+// no pre-image bytes to apply the delta against.
+func (p *diffParser) parseBinaryPatch() (data []byte, isDelta bool) {
+	header, ok := p.peek()
+	if !ok {
+		return nil, false
+	}
+	size, isDelta, ok := parseBinaryPatchHeader(header)
+	if !ok {
+		return nil, false
+	}
+	p.next()
+
+	var encoded []byte
+	for {
+		line, ok := p.peek()
+		if !ok || line == "" {
+			break
+		}
+		p.next()
+
+		n, ok := base85LineLength(line[0])
+		if !ok {
+			continue
+		}
+		decoded, err := decodeBase85Line(line[1:], n)
+		if err != nil {
+			continue
+		}
+		encoded = append(encoded, decoded...)
+	}
+
+	out, err := inflateZlib(encoded)
+	if err != nil {
+		return nil, isDelta
+	}
+	if len(out) > size {
+		out = out[:size]
+	}
+	return out, isDelta
+}
+
+// parseBinaryPatchHeader parses a "literal N" or "delta N" line, returning
+// the decoded size N in bytes and whether it was a delta block.
+func parseBinaryPatchHeader(line string) (size int, isDelta bool, ok bool) {
+	if rest, found := strings.CutPrefix(line, "literal "); found {
+		n, err := strconv.Atoi(strings.TrimSpace(rest))
+		if err != nil {
+			return 0, false, false
+		}
+		return n, false, true
+	}
+	if rest, found := strings.CutPrefix(line, "delta "); found {
+		n, err := strconv.Atoi(strings.TrimSpace(rest))
+		if err != nil {
+			return 0, false, false
+		}
+		return n, true, true
+	}
+	return 0, false, false
+}
+
+// inflateZlib decompresses a zlib-wrapped deflate stream, as used for the
+// body of a "GIT binary patch" block once base85-decoded.
+func inflateZlib(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zlib stream: %w", err)
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate zlib stream: %w", err)
+	}
+	return out, nil
+}
+
+// lfsPointerRegex matches the contents of a Git LFS pointer file, per the
+// spec at https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md.
+var lfsPointerRegex = regexp.MustCompile(`^version https://git-lfs\.github\.com/spec/v1\noid sha256:([0-9a-f]{64})\nsize (\d+)\s*$`)
+
+// detectLFSPointer checks whether the new-side content of a file's hunks is
+// a Git LFS pointer rather than real file content, returning the parsed
+// pointer if so.
+func detectLFSPointer(hunks []Hunk) *LFSPointer {
+	var content strings.Builder
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Type == LineTypeDeleted {
+				continue
+			}
+			content.WriteString(line.Content)
+			content.WriteByte('\n')
+		}
+	}
+
+	matches := lfsPointerRegex.FindStringSubmatch(content.String())
+	if matches == nil {
+		return nil
+	}
+	size, err := strconv.ParseInt(matches[2], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &LFSPointer{OID: matches[1], Size: size}
+}
+
+func (p *diffParser) parseHunk(header string) *Hunk {
+	matches := hunkHeaderRegex.FindStringSubmatch(header)
 	if len(matches) < 5 {
 		return nil
 	}
@@ -115,19 +404,17 @@ func (p *diffParser) parseHunk() *Hunk {
 		hunk.NewLines = 1
 	}
 
-	p.current++
-
 	oldLine := hunk.OldStart
 	newLine := hunk.NewStart
 
-	for p.current < len(p.lines) {
-		if p.current >= len(p.lines) || strings.HasPrefix(p.lines[p.current], "@@") || strings.HasPrefix(p.lines[p.current], "diff --git") {
+	for {
+		line, ok := p.peek()
+		if !ok || strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff --git") {
 			break
 		}
+		p.next()
 
-		line := p.lines[p.current]
 		if len(line) == 0 {
-			p.current++
 			continue
 		}
 
@@ -158,16 +445,191 @@ func (p *diffParser) parseHunk() *Hunk {
 			newLine++
 			lineObj.Content = line[1:]
 		case '\\':
-			p.current++
 			continue
 		default:
-			p.current++
 			continue
 		}
 
 		hunk.Lines = append(hunk.Lines, lineObj)
-		p.current++
+	}
+
+	if p.opts.WordDiff {
+		assignWordDiffSegments(hunk.Lines)
 	}
 
 	return hunk
 }
+
+// assignWordDiffSegments pairs up consecutive deleted/added line runs within
+// a hunk and attaches word-level Segments to each paired line describing
+// what changed. Runs of unequal length are paired by index; any lines left
+// over (including unpaired runs) are left without segments.
+func assignWordDiffSegments(lines []Line) {
+	i := 0
+	for i < len(lines) {
+		if lines[i].Type != LineTypeDeleted {
+			i++
+			continue
+		}
+
+		delStart := i
+		for i < len(lines) && lines[i].Type == LineTypeDeleted {
+			i++
+		}
+		addStart := i
+		for i < len(lines) && lines[i].Type == LineTypeAdded {
+			i++
+		}
+
+		delRun := lines[delStart:addStart]
+		addRun := lines[addStart:i]
+
+		pairs := len(delRun)
+		if len(addRun) < pairs {
+			pairs = len(addRun)
+		}
+
+		for j := 0; j < pairs; j++ {
+			delSegs, addSegs := wordDiff(delRun[j].Content, addRun[j].Content)
+			delRun[j].Segments = delSegs
+			addRun[j].Segments = addSegs
+		}
+	}
+}
+
+// tokenPattern splits a line into whitespace runs, word runs (unicode
+// letters/digits/underscore), and individual punctuation characters, such
+// that concatenating all tokens reproduces the original string exactly.
+var tokenPattern = regexp.MustCompile(`\s+|[\p{L}\p{N}_]+|.`)
+
+func tokenize(s string) []string {
+	return tokenPattern.FindAllString(s, -1)
+}
+
+// wordDiff computes a word/character-level diff between a deleted line and
+// the added line it is paired with, returning the changed byte ranges for
+// each side. It is a simple LCS over whitespace/punctuation-tokenized words,
+// equivalent in spirit to the diff-match-patch output Gitea/Forgejo attach
+// to paired diff lines.
+func wordDiff(oldContent, newContent string) (oldSegments, newSegments []Segment) {
+	oldTokens := tokenize(oldContent)
+	newTokens := tokenize(newContent)
+
+	// Longest common subsequence table.
+	n, m := len(oldTokens), len(newTokens)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldTokens[i] == newTokens[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	oldPos, newPos := 0, 0
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldTokens[i] == newTokens[j]:
+			oldPos += len(oldTokens[i])
+			newPos += len(newTokens[j])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			oldSegments = appendSegment(oldSegments, oldPos, oldPos+len(oldTokens[i]), SegmentRemoved)
+			oldPos += len(oldTokens[i])
+			i++
+		default:
+			newSegments = appendSegment(newSegments, newPos, newPos+len(newTokens[j]), SegmentAdded)
+			newPos += len(newTokens[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		oldSegments = appendSegment(oldSegments, oldPos, oldPos+len(oldTokens[i]), SegmentRemoved)
+		oldPos += len(oldTokens[i])
+	}
+	for ; j < m; j++ {
+		newSegments = appendSegment(newSegments, newPos, newPos+len(newTokens[j]), SegmentAdded)
+		newPos += len(newTokens[j])
+	}
+
+	return oldSegments, newSegments
+}
+
+// appendSegment merges adjacent/overlapping ranges of the same type so that
+// consecutive changed tokens produce one Segment instead of one per token.
+func appendSegment(segments []Segment, start, end int, segType SegmentType) []Segment {
+	if len(segments) > 0 {
+		last := &segments[len(segments)-1]
+		if last.Type == segType && last.End == start {
+			last.End = end
+			return segments
+		}
+	}
+	return append(segments, Segment{Start: start, End: end, Type: segType})
+}
+
+// base85Alphabet is the character set git uses to base85-encode binary
+// patch data, as laid out in Documentation/technical/pack-format in git's
+// own sources (distinct from the Ascii85/Z85 alphabets used elsewhere).
+const base85Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+var base85Decode = func() [256]int8 {
+	var table [256]int8
+	for i := range table {
+		table[i] = -1
+	}
+	for i := 0; i < len(base85Alphabet); i++ {
+		table[base85Alphabet[i]] = int8(i)
+	}
+	return table
+}()
+
+// base85LineLength decodes the length-prefix character git writes at the
+// start of each line of a binary patch block: 'A'-'Z' for 1-26 bytes and
+// 'a'-'z' for 27-52 bytes.
+func base85LineLength(c byte) (int, bool) {
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 1, true
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 27, true
+	default:
+		return 0, false
+	}
+}
+
+// decodeBase85Line decodes the base85 body of a single binary patch line
+// (everything after the length-prefix character) into n raw bytes.
+func decodeBase85Line(body string, n int) ([]byte, error) {
+	groups := (n + 3) / 4
+	if len(body) < groups*5 {
+		return nil, fmt.Errorf("base85 line too short: want %d chars, got %d", groups*5, len(body))
+	}
+
+	out := make([]byte, 0, groups*4)
+	for g := 0; g < groups; g++ {
+		var val uint32
+		for _, c := range body[g*5 : g*5+5] {
+			idx := base85Decode[byte(c)]
+			if idx < 0 {
+				return nil, fmt.Errorf("invalid base85 character %q", c)
+			}
+			val = val*85 + uint32(idx)
+		}
+		out = append(out, byte(val>>24), byte(val>>16), byte(val>>8), byte(val))
+	}
+
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out, nil
+}
@@ -0,0 +1,161 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWordDiffUnicode(t *testing.T) {
+	oldSegs, newSegs := wordDiff("café 日本", "café 中国")
+	if len(oldSegs) != 1 || oldSegs[0].Type != SegmentRemoved {
+		t.Fatalf("old segments = %+v, want a single removed segment", oldSegs)
+	}
+	if len(newSegs) != 1 || newSegs[0].Type != SegmentAdded {
+		t.Fatalf("new segments = %+v, want a single added segment", newSegs)
+	}
+	if got := "café 日本"[oldSegs[0].Start:oldSegs[0].End]; got != "日本" {
+		t.Errorf("old segment text = %q, want %q", got, "日本")
+	}
+	if got := "café 中国"[newSegs[0].Start:newSegs[0].End]; got != "中国" {
+		t.Errorf("new segment text = %q, want %q", got, "中国")
+	}
+}
+
+func TestWordDiffTrailingWhitespace(t *testing.T) {
+	oldSegs, newSegs := wordDiff("foo bar", "foo bar  ")
+	if len(oldSegs) != 0 {
+		t.Errorf("old segments = %+v, want none", oldSegs)
+	}
+	if len(newSegs) != 1 || newSegs[0].Type != SegmentAdded {
+		t.Fatalf("new segments = %+v, want a single added segment", newSegs)
+	}
+	if got := "foo bar  "[newSegs[0].Start:newSegs[0].End]; got != "  " {
+		t.Errorf("new segment text = %q, want two trailing spaces", got)
+	}
+}
+
+func TestWordDiffPureWhitespaceChange(t *testing.T) {
+	oldSegs, newSegs := wordDiff("foo  bar", "foo\tbar")
+	if len(oldSegs) != 1 || oldSegs[0].Type != SegmentRemoved {
+		t.Fatalf("old segments = %+v, want a single removed segment", oldSegs)
+	}
+	if len(newSegs) != 1 || newSegs[0].Type != SegmentAdded {
+		t.Fatalf("new segments = %+v, want a single added segment", newSegs)
+	}
+	if got := "foo  bar"[oldSegs[0].Start:oldSegs[0].End]; got != "  " {
+		t.Errorf("old segment text = %q, want two spaces", got)
+	}
+	if got := "foo\tbar"[newSegs[0].Start:newSegs[0].End]; got != "\t" {
+		t.Errorf("new segment text = %q, want a tab", got)
+	}
+}
+
+func TestAssignWordDiffSegmentsAsymmetricRuns(t *testing.T) {
+	// Two deleted lines paired with a single added line: only the first
+	// deleted line should be word-diffed against the added line, and the
+	// second deleted line should be left without segments.
+	lines := []Line{
+		{Type: LineTypeDeleted, Content: "foo bar"},
+		{Type: LineTypeDeleted, Content: "baz qux"},
+		{Type: LineTypeAdded, Content: "foo quux"},
+	}
+	assignWordDiffSegments(lines)
+
+	if len(lines[0].Segments) == 0 {
+		t.Errorf("lines[0].Segments = %+v, want segments from pairing with the added line", lines[0].Segments)
+	}
+	if len(lines[1].Segments) != 0 {
+		t.Errorf("lines[1].Segments = %+v, want none (no added line left to pair with)", lines[1].Segments)
+	}
+	if len(lines[2].Segments) == 0 {
+		t.Errorf("lines[2].Segments = %+v, want segments from pairing with lines[0]", lines[2].Segments)
+	}
+}
+
+// encodeBase85Patch builds the base85 block git's "GIT binary patch" format
+// expects: a length-prefixed, 52-bytes-per-line encoding of data, terminated
+// by a blank line. It's the test-only inverse of decodeBase85Line, used to
+// round-trip parseBinaryPatch against content this test controls.
+func encodeBase85Patch(data []byte) string {
+	var out strings.Builder
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > 52 {
+			chunk = chunk[:52]
+		}
+		data = data[len(chunk):]
+
+		if len(chunk) <= 26 {
+			out.WriteByte('A' + byte(len(chunk)-1))
+		} else {
+			out.WriteByte('a' + byte(len(chunk)-27))
+		}
+
+		padded := make([]byte, ((len(chunk)+3)/4)*4)
+		copy(padded, chunk)
+		for g := 0; g < len(padded); g += 4 {
+			val := uint32(padded[g])<<24 | uint32(padded[g+1])<<16 | uint32(padded[g+2])<<8 | uint32(padded[g+3])
+			var digits [5]byte
+			for i := 4; i >= 0; i-- {
+				digits[i] = base85Alphabet[val%85]
+				val /= 85
+			}
+			out.Write(digits[:])
+		}
+		out.WriteByte('\n')
+	}
+	out.WriteByte('\n')
+	return out.String()
+}
+
+func TestParseBinaryPatchRoundTrip(t *testing.T) {
+	want := []byte("the quick brown fox jumps over the lazy dog, 0123456789")
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(want); err != nil {
+		t.Fatalf("zlib.Write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zlib.Close: %v", err)
+	}
+
+	diffText := fmt.Sprintf(
+		"diff --git a/file.bin b/file.bin\nnew file mode 100644\nindex 0000000..abcdef1\nGIT binary patch\nliteral %d\n%s",
+		len(want), encodeBase85Patch(compressed.Bytes()),
+	)
+
+	var got *FileDiff
+	err := newDiffParser(strings.NewReader(diffText), DiffOptions{IncludeBinary: true}).parse(func(fd FileDiff) error {
+		got = &fd
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got == nil {
+		t.Fatal("parse produced no FileDiff")
+	}
+	if got.BinaryPatchIsDelta {
+		t.Error("BinaryPatchIsDelta = true, want false for a literal block")
+	}
+	if string(got.BinaryPatch) != string(want) {
+		t.Errorf("BinaryPatch = %q, want %q", got.BinaryPatch, want)
+	}
+}
+
+func TestTokenizeUnicode(t *testing.T) {
+	got := tokenize("café, 中国!")
+	want := []string{"café", ",", " ", "中国", "!"}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -1,73 +1,129 @@
 package git
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"strings"
 )
 
-type Service struct{}
+// Service is the repository-facing API the rest of the app talks to. It
+// delegates to a Backend for the actual git access, defaulting to
+// ExecBackend (shelling out to the git binary) so existing callers don't
+// need to change.
+type Service struct {
+	backend Backend
+}
 
-func NewService() *Service {
-	return &Service{}
+// NewService constructs a Service, defaulting to ExecBackend when no
+// Backend is passed.
+func NewService(backend ...Backend) *Service {
+	var b Backend
+	if len(backend) > 0 && backend[0] != nil {
+		b = backend[0]
+	} else {
+		b = NewExecBackend()
+	}
+	return &Service{backend: b}
 }
 
-// GetDiff retrieves the git diff with optional context lines (default: 3)
-func (s *Service) GetDiff(diffType DiffType, contextLines ...int) (*DiffResult, error) {
-	context := 3
-	if len(contextLines) > 0 {
-		context = contextLines[0]
+// execBackend returns the Service's backend as an *ExecBackend, for the
+// handful of methods below (StreamDiff, GetCommitDiff, GetRangeDiff,
+// GetBlobDiff, GetRawDiff) that rely on git-CLI-only features and have no
+// equivalent in the Backend interface.
+func (s *Service) execBackend() (*ExecBackend, error) {
+	eb, ok := s.backend.(*ExecBackend)
+	if !ok {
+		return nil, fmt.Errorf("this operation requires an ExecBackend, got %T", s.backend)
 	}
+	return eb, nil
+}
 
-	var args []string
+// GetDiff retrieves the git diff with optional context lines (default: 3).
+// Pass a DiffOptions to set the context explicitly, enable word-level diff
+// highlighting, or bound memory use on very large diffs.
+func (s *Service) GetDiff(diffType DiffType, opts ...DiffOptions) (*DiffResult, error) {
+	var opt DiffOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return s.backend.GetDiff(context.Background(), diffType, opt)
+}
 
-	switch diffType {
-	case DiffTypeStaged:
-		args = []string{"diff", "--cached", "--no-color", "--no-ext-diff"}
-	case DiffTypeUnstaged:
-		args = []string{"diff", "--no-color", "--no-ext-diff"}
-	default:
-		args = []string{"diff", "HEAD", "--no-color", "--no-ext-diff"}
+// StreamDiff runs git diff for diffType and invokes fn once per completed
+// FileDiff as soon as it is parsed, so callers never need to hold the whole
+// diff output (or the full file list) in memory at once. ctx can be used to
+// cancel a long-running git invocation, e.g. on an enormous repository.
+// Requires an ExecBackend.
+func (s *Service) StreamDiff(ctx context.Context, diffType DiffType, fn func(FileDiff) error, opts ...DiffOptions) error {
+	eb, err := s.execBackend()
+	if err != nil {
+		return err
 	}
 
-	// Add context parameter
-	if context >= 0 {
-		args = append(args, fmt.Sprintf("-U%d", context))
+	var opt DiffOptions
+	if len(opts) > 0 {
+		opt = opts[0]
 	}
+	return eb.StreamDiff(ctx, diffType, fn, opt)
+}
 
-	output, err := s.runGitCommand(args...)
+// GetCommitDiff returns the diff introduced by a single commit, equivalent
+// to `git show <ref>`. Requires an ExecBackend.
+func (s *Service) GetCommitDiff(ref string, opts ...DiffOptions) (*DiffResult, error) {
+	eb, err := s.execBackend()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get diff: %w", err)
+		return nil, err
 	}
 
-	files, err := s.parseDiff(output)
+	var opt DiffOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return eb.GetCommitDiff(ref, opt)
+}
+
+// GetRangeDiff returns the diff between two commits, equivalent to
+// `git diff <from>..<to>`. Requires an ExecBackend.
+func (s *Service) GetRangeDiff(from, to string, opts ...DiffOptions) (*DiffResult, error) {
+	eb, err := s.execBackend()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse diff: %w", err)
-	}
-
-	// Get untracked files and add them to the diff
-	if diffType == DiffTypeUnstaged || diffType == DiffTypeAll {
-		untrackedFiles, err := s.getUntrackedFiles()
-		if err == nil && len(untrackedFiles) > 0 {
-			for _, filepath := range untrackedFiles {
-				fileDiff, err := s.getUntrackedFileDiff(filepath, context)
-				if err == nil && fileDiff != nil {
-					files = append(files, *fileDiff)
-				}
-			}
-		}
+		return nil, err
 	}
 
-	return &DiffResult{
-		Files: files,
-		Type:  diffType,
-	}, nil
+	var opt DiffOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return eb.GetRangeDiff(from, to, opt)
+}
+
+// GetBlobDiff diffs two blob objects directly by SHA, independent of any
+// commit, branch, or path, equivalent to `git diff <oldSha> <newSha>`.
+// Requires an ExecBackend.
+func (s *Service) GetBlobDiff(oldSha, newSha string) (*FileDiff, error) {
+	eb, err := s.execBackend()
+	if err != nil {
+		return nil, err
+	}
+	return eb.GetBlobDiff(oldSha, newSha)
+}
+
+// GetRawDiff writes the raw textual diff for spec to w without going through
+// FileDiff at all, so callers (e.g. an HTTP handler serving a patch
+// download) can stream git's own output straight through. Requires an
+// ExecBackend.
+func (s *Service) GetRawDiff(spec DiffSpec, format RawDiffFormat, w io.Writer) error {
+	eb, err := s.execBackend()
+	if err != nil {
+		return err
+	}
+	return eb.GetRawDiff(spec, format, w)
 }
 
 func (s *Service) GetStatus() ([]string, error) {
-	output, err := s.runGitCommand("status", "--porcelain")
+	output, err := s.backend.RunGitCommand("status", "--porcelain")
 	if err != nil {
 		return nil, err
 	}
@@ -83,63 +139,56 @@ func (s *Service) GetStatus() ([]string, error) {
 	return files, nil
 }
 
-func (s *Service) runGitCommand(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return "", fmt.Errorf("git command failed: %s", stderr.String())
-	}
-
-	return out.String(), nil
+func (s *Service) GetFileContent(filePath string) (string, error) {
+	return s.backend.GetFileContent(filePath)
 }
 
-func (s *Service) parseDiff(diffOutput string) ([]FileDiff, error) {
-	if diffOutput == "" {
-		return []FileDiff{}, nil
+// postImageContent reads filename's content on the diffType-appropriate
+// post-image side: the index for DiffTypeStaged, or the working tree for
+// DiffTypeUnstaged/DiffTypeAll. Unlike GetFileContent (which prefers HEAD,
+// the pre-image), this is what ExpandHunk needs to index by a hunk's
+// post-image (new-side) line numbers.
+func (s *Service) postImageContent(filename string, diffType DiffType) (string, error) {
+	if diffType == DiffTypeStaged {
+		content, err := s.backend.RunGitCommand("show", fmt.Sprintf(":%s", filename))
+		if err != nil {
+			return "", fmt.Errorf("failed to read staged content: %w", err)
+		}
+		return content, nil
 	}
 
-	parser := newDiffParser(diffOutput)
-	return parser.parse()
-}
-
-func (s *Service) GetFileContent(filePath string) (string, error) {
-	// First check if file exists in working directory
-	content, err := s.runGitCommand("show", fmt.Sprintf("HEAD:%s", filePath))
+	content, err := os.ReadFile(filename)
 	if err != nil {
-		// If not in HEAD, try to read from filesystem
-		output, err := os.ReadFile(filePath)
-		if err != nil {
-			return "", fmt.Errorf("failed to read file: %w", err)
-		}
-		return string(output), nil
+		return "", fmt.Errorf("failed to read file: %w", err)
 	}
-	return content, nil
+	return string(content), nil
 }
 
-// GetFileDiff retrieves diff for a specific file with optional context lines
-func (s *Service) GetFileDiff(filename string, diffType DiffType, contextLines ...int) (*FileDiff, error) {
-	context := 3
-	if len(contextLines) > 0 {
-		context = contextLines[0]
+// GetFileDiff retrieves diff for a specific file, with optional DiffOptions
+// to control context lines or enable word-level diff highlighting.
+func (s *Service) GetFileDiff(filename string, diffType DiffType, opts ...DiffOptions) (*FileDiff, error) {
+	var opt DiffOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	contextLines := 3
+	if opt.ContextLines != nil {
+		contextLines = *opt.ContextLines
 	}
 
 	// Check if it's an untracked file
-	untrackedFiles, err := s.getUntrackedFiles()
+	untrackedFiles, err := s.backend.GetUntrackedFiles()
 	if err == nil {
 		for _, untracked := range untrackedFiles {
 			if untracked == filename {
-				return s.getUntrackedFileDiff(filename, context)
+				return getUntrackedFileDiff(filename, contextLines)
 			}
 		}
 	}
 
 	// Otherwise get from regular diff
-	diff, err := s.GetDiff(diffType, contextLines...)
+	diff, err := s.GetDiff(diffType, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -155,42 +204,112 @@ func (s *Service) GetFileDiff(filename string, diffType DiffType, contextLines .
 
 // GetFileDiffWithFullContext is a convenience method for getting full file context
 func (s *Service) GetFileDiffWithFullContext(filename string, diffType DiffType) (*FileDiff, error) {
-	return s.GetFileDiff(filename, diffType, 999999)
+	fullContext := 999999
+	return s.GetFileDiff(filename, diffType, DiffOptions{ContextLines: &fullContext})
 }
 
-// getUntrackedFiles returns list of untracked files from git status
-func (s *Service) getUntrackedFiles() ([]string, error) {
-	output, err := s.runGitCommand("ls-files", "--others", "--exclude-standard")
+// ExpandHunk reveals additional unchanged context lines adjacent to the
+// hunk at hunkIndex, synthesized directly from the post-image file content
+// rather than by re-running git diff with a larger -U value for the whole
+// file (what GetFileDiffWithFullContext does). direction picks which side
+// of the hunk to reveal; lines caps how many lines are added on each side
+// picked, except for ExpandAll, which always reveals the full gap up to
+// the previous/next hunk (or the start/end of the file).
+func (s *Service) ExpandHunk(filename string, diffType DiffType, hunkIndex int, direction ExpandDirection, lines int) ([]Line, error) {
+	fileDiff, err := s.GetFileDiff(filename, diffType)
 	if err != nil {
 		return nil, err
 	}
+	if hunkIndex < 0 || hunkIndex >= len(fileDiff.Hunks) {
+		return nil, fmt.Errorf("hunk index %d out of range for %s (%d hunks)", hunkIndex, filename, len(fileDiff.Hunks))
+	}
+	hunk := fileDiff.Hunks[hunkIndex]
 
-	if output == "" {
-		return []string{}, nil
+	content, err := s.postImageContent(filename, diffType)
+	if err != nil {
+		return nil, err
 	}
+	fileLines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
 
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	var files []string
-	for _, line := range lines {
-		if line != "" {
-			files = append(files, line)
+	var expanded []Line
+
+	if direction == ExpandUp || direction == ExpandUpDown || direction == ExpandAll {
+		upperBound := 1
+		if hunkIndex > 0 {
+			prev := fileDiff.Hunks[hunkIndex-1]
+			upperBound = prev.NewStart + prev.NewLines
+		}
+
+		newStart := hunk.NewStart - lines
+		if direction == ExpandAll {
+			newStart = upperBound
+		}
+		if newStart < upperBound {
+			newStart = upperBound
+		}
+
+		offset := hunk.NewStart - hunk.OldStart
+		for n := newStart; n < hunk.NewStart; n++ {
+			if n < 1 || n > len(fileLines) {
+				continue
+			}
+			newNum := n
+			oldNum := n - offset
+			expanded = append(expanded, Line{
+				Type:      LineTypeContext,
+				OldNumber: &oldNum,
+				NewNumber: &newNum,
+				Content:   fileLines[n-1],
+			})
 		}
 	}
 
-	return files, nil
+	if direction == ExpandDown || direction == ExpandUpDown || direction == ExpandAll {
+		lowerBound := len(fileLines)
+		if hunkIndex < len(fileDiff.Hunks)-1 {
+			next := fileDiff.Hunks[hunkIndex+1]
+			lowerBound = next.NewStart - 1
+		}
+
+		hunkNewEnd := hunk.NewStart + hunk.NewLines
+		newEnd := hunkNewEnd + lines - 1
+		if direction == ExpandAll {
+			newEnd = lowerBound
+		}
+		if newEnd > lowerBound {
+			newEnd = lowerBound
+		}
+
+		offset := hunkNewEnd - (hunk.OldStart + hunk.OldLines)
+		for n := hunkNewEnd; n <= newEnd; n++ {
+			if n < 1 || n > len(fileLines) {
+				continue
+			}
+			newNum := n
+			oldNum := n - offset
+			expanded = append(expanded, Line{
+				Type:      LineTypeContext,
+				OldNumber: &oldNum,
+				NewNumber: &newNum,
+				Content:   fileLines[n-1],
+			})
+		}
+	}
+
+	return expanded, nil
 }
 
-// getUntrackedFileDiff creates a diff for an untracked file
-func (s *Service) getUntrackedFileDiff(filepath string, contextLines int) (*FileDiff, error) {
-	// Read file content
+// getUntrackedFileDiff creates a diff for an untracked file by reading it
+// straight off disk; this is backend-independent since an untracked file
+// has no git object to diff against.
+func getUntrackedFileDiff(filepath string, contextLines int) (*FileDiff, error) {
 	content, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read untracked file %s: %w", filepath, err)
 	}
 
 	lines := strings.Split(string(content), "\n")
-	
-	// Create diff lines showing all lines as added
+
 	var diffLines []Line
 	for i, line := range lines {
 		lineNum := i + 1
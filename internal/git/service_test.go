@@ -0,0 +1,105 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// initTestRepo creates a git repository in a temp directory, chdirs into it
+// for the duration of the test, and restores the original working directory
+// on cleanup (ExecBackend always operates on the process's cwd).
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("os.Chdir: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(oldWd); err != nil {
+			t.Fatalf("restoring cwd: %v", err)
+		}
+	})
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		cmd := exec.Command("git", args...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	return dir
+}
+
+func TestExpandHunkReadsPostImageAfterLineShift(t *testing.T) {
+	initTestRepo(t)
+
+	const total = 40
+	oldLines := make([]string, total)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("line%d", i+1)
+	}
+	if err := os.WriteFile("file.txt", []byte(strings.Join(oldLines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	for _, args := range [][]string{{"add", "file.txt"}, {"commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	// Insert two lines near the top (shifting everything below by 2) and
+	// change one line far enough below that it forms a second, separate
+	// hunk from the insertion.
+	var newLines []string
+	newLines = append(newLines, oldLines[:2]...)
+	newLines = append(newLines, "inserted-a", "inserted-b")
+	newLines = append(newLines, oldLines[2:]...)
+	const changedOldIdx = 29 // 0-based index into oldLines
+	changedNewIdx := changedOldIdx + 2
+	newLines[changedNewIdx] = "changed"
+
+	if err := os.WriteFile("file.txt", []byte(strings.Join(newLines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	s := NewService(NewExecBackend())
+	fileDiff, err := s.GetFileDiff("file.txt", DiffTypeUnstaged)
+	if err != nil {
+		t.Fatalf("GetFileDiff: %v", err)
+	}
+	if len(fileDiff.Hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2 (one for the insert, one for the later change)", len(fileDiff.Hunks))
+	}
+
+	expanded, err := s.ExpandHunk("file.txt", DiffTypeUnstaged, 1, ExpandUp, 5)
+	if err != nil {
+		t.Fatalf("ExpandHunk: %v", err)
+	}
+	if len(expanded) == 0 {
+		t.Fatal("ExpandHunk returned no lines")
+	}
+
+	for _, line := range expanded {
+		if line.NewNumber == nil {
+			t.Fatalf("expanded line has nil NewNumber: %+v", line)
+		}
+		want := newLines[*line.NewNumber-1]
+		if line.Content != want {
+			t.Errorf("expanded line new=%d content = %q, want %q (post-image, not pre-image)", *line.NewNumber, line.Content, want)
+		}
+	}
+}
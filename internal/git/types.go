@@ -0,0 +1,191 @@
+package git
+
+// DiffType identifies which part of the working tree a diff is computed against.
+type DiffType string
+
+const (
+	DiffTypeStaged   DiffType = "staged"
+	DiffTypeUnstaged DiffType = "unstaged"
+	DiffTypeAll      DiffType = "all"
+)
+
+// ExpandDirection selects which side of a hunk Service.ExpandHunk reveals
+// additional context lines on.
+type ExpandDirection string
+
+const (
+	ExpandUp     ExpandDirection = "up"
+	ExpandDown   ExpandDirection = "down"
+	ExpandUpDown ExpandDirection = "up_down"
+	ExpandAll    ExpandDirection = "all"
+)
+
+// FileStatus describes how a file changed within a diff.
+type FileStatus string
+
+const (
+	FileStatusAdded    FileStatus = "added"
+	FileStatusDeleted  FileStatus = "deleted"
+	FileStatusModified FileStatus = "modified"
+	FileStatusRenamed  FileStatus = "renamed"
+	FileStatusCopied   FileStatus = "copied"
+)
+
+// LineType describes the role of a single line within a hunk.
+type LineType string
+
+const (
+	LineTypeContext LineType = "context"
+	LineTypeAdded   LineType = "added"
+	LineTypeDeleted LineType = "deleted"
+)
+
+// SegmentType describes how a sub-line Segment differs from its paired line.
+type SegmentType string
+
+const (
+	SegmentAdded   SegmentType = "added"
+	SegmentRemoved SegmentType = "removed"
+)
+
+// Segment marks a byte range within Line.Content that changed relative to the
+// line it is paired with (a deleted line pairs with the added line that
+// replaced it, and vice versa).
+type Segment struct {
+	Start int
+	End   int
+	Type  SegmentType
+}
+
+// Line is a single line within a Hunk.
+type Line struct {
+	Type      LineType
+	OldNumber *int
+	NewNumber *int
+	Content   string
+	Segments  []Segment
+}
+
+// Hunk is a contiguous block of changed lines along with surrounding context.
+type Hunk struct {
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// FileDiff describes the changes made to a single file.
+type FileDiff struct {
+	Path      string
+	OldPath   string
+	Status    FileStatus
+	Additions int
+	Deletions int
+	IsBinary  bool
+	Hunks     []Hunk
+
+	// Similarity is the percentage (0-100) git reports the old and new
+	// content matching by. It is only meaningful for FileStatusRenamed and
+	// FileStatusCopied files, and is set whenever git's output carries a
+	// "similarity index" header, as it does for renames and copies when
+	// DiffOptions.DetectRenames is set.
+	Similarity int
+
+	// BinaryPatch holds the "GIT binary patch" block git emits for this
+	// file, base85-decoded and zlib-inflated. When BinaryPatchIsDelta is
+	// false these are the literal new-file bytes. When true, git chose to
+	// encode this block as a delta against the pre-image blob instead of
+	// the literal content; parser.go only sees the diff text stream (not
+	// the pre-image blob), so it cannot apply the delta, and BinaryPatch
+	// holds the raw (still delta-encoded) bytes in that case. Only
+	// populated when IsBinary is true and DiffOptions.IncludeBinary was
+	// requested.
+	BinaryPatch []byte
+
+	// BinaryPatchIsDelta reports whether BinaryPatch is delta-encoded
+	// against the pre-image blob (a "delta N" block) rather than literal
+	// new-file content (a "literal N" block). See BinaryPatch's comment.
+	BinaryPatchIsDelta bool
+
+	// LFS is set when an added or modified file's content is a Git LFS
+	// pointer (rather than the real file content), parsed from its
+	// "version"/"oid sha256:.../size N" lines.
+	LFS *LFSPointer
+}
+
+// LFSPointer describes the target of a Git LFS pointer file, as parsed from
+// its "oid sha256:..." and "size N" lines.
+type LFSPointer struct {
+	OID  string
+	Size int64
+}
+
+// DiffResult is the outcome of a diff request across one or more files.
+type DiffResult struct {
+	Files []FileDiff
+	Type  DiffType
+}
+
+// RawDiffFormat selects the textual format Service.GetRawDiff writes.
+type RawDiffFormat string
+
+const (
+	// RawDiffNormal writes the raw output of `git diff`/`git show`.
+	RawDiffNormal RawDiffFormat = "normal"
+	// RawDiffPatch writes the raw output of `git format-patch --stdout`,
+	// a mailbox-formatted patch suitable for `git am`.
+	RawDiffPatch RawDiffFormat = "patch"
+)
+
+// DiffSpec identifies what Service.GetRawDiff should diff: either a single
+// commit (Ref) or a commit range (From..To).
+type DiffSpec struct {
+	// Ref selects a single commit, e.g. for `git show`/`git format-patch -1`.
+	// Ignored if From is set.
+	Ref string
+
+	// From and To select a commit range, e.g. for `git diff From..To`. To
+	// defaults to "HEAD" when From is set and To is empty.
+	From string
+	To   string
+}
+
+// DiffOptions configures how a diff is generated and parsed.
+type DiffOptions struct {
+	// ContextLines overrides the number of context lines around each hunk.
+	// nil means use the default (3).
+	ContextLines *int
+
+	// WordDiff enables sub-line change highlighting: paired deleted/added
+	// lines within a hunk are further diffed word-by-word and the resulting
+	// change ranges are attached to each Line as Segments.
+	WordDiff bool
+
+	// MaxLineLength truncates any diff line longer than this many bytes.
+	// The parser's line buffer is capped at MaxLineLength+1024 bytes (16 MiB
+	// when MaxLineLength is 0 or larger than that), and a line that does
+	// not fit in it stops parsing with an error instead of being truncated.
+	// 0 means no truncation.
+	MaxLineLength int
+
+	// MaxFiles stops parsing after this many files have been emitted.
+	// 0 means unlimited.
+	MaxFiles int
+
+	// DetectRenames enables git's rename and copy detection (-M/-C), which
+	// reports moved or duplicated files as FileStatusRenamed/FileStatusCopied
+	// with a Similarity score instead of a delete+add pair.
+	DetectRenames bool
+
+	// RenameSimilarity overrides the minimum similarity percentage (1-100)
+	// git requires to consider two files a rename/copy pair. Only used when
+	// DetectRenames is set; 0 uses git's own default (50%).
+	RenameSimilarity int
+
+	// IncludeBinary passes --binary to git diff so binary files carry a
+	// "GIT binary patch" block instead of just a "Binary files ... differ"
+	// line, and populates FileDiff.BinaryPatch with its decoded bytes.
+	IncludeBinary bool
+}